@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+// ConsistentHash picks a host by hashing a per-request key (the configured
+// header/cookie, falling back to source IP) onto a ring of virtual nodes.
+// IPHash and HeaderHash are the same algorithm with a single default key
+// source (the source IP, or the default header) instead of ConsistentHash's
+// header, then cookie, then source IP order. A LoadBalancerConsistentHashConfig
+// with a Name replaces the key source of any of the three.
+const (
+	ConsistentHash LoadBalancerType = "ConsistentHash"
+	IPHash         LoadBalancerType = "IPHash"
+	HeaderHash     LoadBalancerType = "HeaderHash"
+)
+
+// LoadBalancerHashKeySource selects where a ConsistentHash load balancer
+// reads its per-request hash key from.
+type LoadBalancerHashKeySource int
+
+const (
+	LoadBalancerHashKeySourceHeader LoadBalancerHashKeySource = iota
+	LoadBalancerHashKeySourceCookie
+)
+
+// LoadBalancerConsistentHashConfig overrides the header/cookie name a
+// ConsistentHash-family load balancer extracts its ring hash key from.
+type LoadBalancerConsistentHashConfig struct {
+	Source LoadBalancerHashKeySource
+	Name   string
+}
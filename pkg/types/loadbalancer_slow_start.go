@@ -0,0 +1,35 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import "time"
+
+// LoadBalancerSlowStartConfig ramps a newly-joined host's effective weight
+// up gradually over Window instead of giving it full weight immediately, so
+// a cold host doesn't take a full share of traffic before it's warmed up.
+type LoadBalancerSlowStartConfig struct {
+	// Window is how long a host's weight ramp takes. A zero Window disables
+	// slow start.
+	Window time.Duration
+	// Aggression shapes the ramp curve: scale = (elapsed/Window)^Aggression.
+	// Defaults to 1 (linear) when unset.
+	Aggression float64
+	// Cooldown is how long a host can be absent from the HostSet and still
+	// resume its ramp progress on rejoin instead of starting over.
+	Cooldown time.Duration
+}
@@ -0,0 +1,40 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import "time"
+
+// LoadBalancerStickyMode selects whether sticky-session affinity is carried
+// by a cookie or a header.
+type LoadBalancerStickyMode int
+
+const (
+	LoadBalancerStickyModeCookie LoadBalancerStickyMode = iota
+	LoadBalancerStickyModeHeader
+)
+
+// LoadBalancerStickyConfig configures cookie/header affinity for a cluster.
+// TTL and Secure only apply to cookie mode: they're carried through to the
+// HTTP layer's Set-Cookie Max-Age and Secure attributes when it stamps the
+// sticky cookie onto a response.
+type LoadBalancerStickyConfig struct {
+	Mode   LoadBalancerStickyMode
+	Name   string
+	TTL    time.Duration
+	Secure bool
+}
@@ -0,0 +1,43 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+// LoadBalancerSubsetFallbackPolicy selects what a subset-aware load balancer
+// does when a request's match criteria doesn't resolve to a known subset.
+type LoadBalancerSubsetFallbackPolicy int
+
+const (
+	// LoadBalancerSubsetFallbackAny selects from the whole, unrestricted
+	// HostSet. It's the zero value, so a config that doesn't set Fallback
+	// behaves the same as explicitly requesting it.
+	LoadBalancerSubsetFallbackAny LoadBalancerSubsetFallbackPolicy = iota
+	// LoadBalancerSubsetFallbackDefault selects from the subset matching
+	// DefaultSubset, if one is configured.
+	LoadBalancerSubsetFallbackDefault
+	// LoadBalancerSubsetFallbackNone returns no host at all.
+	LoadBalancerSubsetFallbackNone
+)
+
+// LoadBalancerSubsetConfig restricts a cluster's load balancer to the subset
+// of hosts matching a request's metadata match criteria, grouped by the
+// values of Keys.
+type LoadBalancerSubsetConfig struct {
+	Keys          []string
+	Fallback      LoadBalancerSubsetFallbackPolicy
+	DefaultSubset map[string]string
+}
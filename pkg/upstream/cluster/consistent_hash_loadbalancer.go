@@ -0,0 +1,292 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// consistentHashVirtualNodes is the number of virtual nodes placed on the ring
+// for a host with weight 1. Hosts with a higher weight get proportionally more.
+const consistentHashVirtualNodes = 160
+
+// defaultConsistentHashHeader/Cookie are used when a cluster doesn't override
+// the hash key source via LoadBalancerConsistentHashConfig.
+const (
+	defaultConsistentHashHeader = "x-mosn-lb-key"
+	defaultConsistentHashCookie = "mosn-lb-key"
+)
+
+// consistentHashConfigurable is implemented by load balancers that support a
+// per-cluster override of the header/cookie used to extract the ring hash
+// key (consistentHashLoadBalancer).
+type consistentHashConfigurable interface {
+	configureConsistentHash(config types.LoadBalancerConsistentHashConfig)
+}
+
+func applyConsistentHashConfig(lb types.LoadBalancer, config types.LoadBalancerConsistentHashConfig) {
+	if config.Name == "" {
+		return
+	}
+	if configurable, ok := lb.(consistentHashConfigurable); ok {
+		configurable.configureConsistentHash(config)
+	}
+}
+
+// hashKeyFunc extracts the bytes used to compute a request's ring hash from
+// the load balancer context. The second return value is false when the
+// context does not carry the configured key (e.g. header not present).
+type hashKeyFunc func(context types.LoadBalancerContext) ([]byte, bool)
+
+func ipHashKey(context types.LoadBalancerContext) ([]byte, bool) {
+	conn := context.DownstreamConnection()
+	if conn == nil {
+		return nil, false
+	}
+	addr := conn.RemoteAddr()
+	if addr == nil {
+		return nil, false
+	}
+	return []byte(addr.String()), true
+}
+
+func headerHashKey(name string) hashKeyFunc {
+	return func(context types.LoadBalancerContext) ([]byte, bool) {
+		headers := context.DownstreamHeaders()
+		if headers == nil {
+			return nil, false
+		}
+		value, ok := headers.Get(name)
+		if !ok || value == "" {
+			return nil, false
+		}
+		return []byte(value), true
+	}
+}
+
+func cookieHashKey(name string) hashKeyFunc {
+	return func(context types.LoadBalancerContext) ([]byte, bool) {
+		headers := context.DownstreamHeaders()
+		if headers == nil {
+			return nil, false
+		}
+		raw, ok := headers.Get("cookie")
+		if !ok || raw == "" {
+			return nil, false
+		}
+		value := parseCookie(raw, name)
+		if value == "" {
+			return nil, false
+		}
+		return []byte(value), true
+	}
+}
+
+// parseCookie extracts a single cookie value from a raw "Cookie" header.
+func parseCookie(raw, name string) string {
+	for _, part := range strings.Split(raw, ";") {
+		part = strings.TrimSpace(part)
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 && kv[0] == name {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+// genericHashKey is used by the plain ConsistentHash LB type: it tries the
+// default header, then the default cookie, then falls back to the source IP.
+func genericHashKey(context types.LoadBalancerContext) ([]byte, bool) {
+	if key, ok := headerHashKey(defaultConsistentHashHeader)(context); ok {
+		return key, true
+	}
+	if key, ok := cookieHashKey(defaultConsistentHashCookie)(context); ok {
+		return key, true
+	}
+	return ipHashKey(context)
+}
+
+// hashRing is an immutable snapshot of hashed virtual nodes for the currently
+// healthy hosts, sorted by hash so ChooseHost can binary search it.
+type hashRing struct {
+	points []uint32
+	hosts  []types.Host
+
+	// sourceLen/sourcePtr identify the []types.Host slice this ring was built
+	// from, so callers can cheaply detect whether HostSet has changed without
+	// rebuilding the ring on every pick.
+	sourceLen int
+	sourcePtr uintptr
+}
+
+func (r *hashRing) choose(hash uint32) types.Host {
+	total := len(r.points)
+	if total == 0 {
+		return nil
+	}
+	idx := sort.Search(total, func(i int) bool {
+		return r.points[i] >= hash
+	})
+	if idx == total {
+		idx = 0
+	}
+	for i := 0; i < total; i++ {
+		host := r.hosts[(idx+i)%total]
+		if host.Health() {
+			return host
+		}
+	}
+	return nil
+}
+
+func hostSliceIdentity(hosts []types.Host) (int, uintptr) {
+	if len(hosts) == 0 {
+		return 0, 0
+	}
+	return len(hosts), reflect.ValueOf(hosts).Pointer()
+}
+
+func buildHashRing(hosts []types.Host) *hashRing {
+	type vnode struct {
+		hash uint32
+		host types.Host
+	}
+	vnodes := make([]vnode, 0, len(hosts)*consistentHashVirtualNodes)
+	for _, host := range hosts {
+		weight := host.Weight()
+		if weight == 0 {
+			weight = 1
+		}
+		count := int(weight) * consistentHashVirtualNodes
+		for i := 0; i < count; i++ {
+			key := host.AddressString() + "#" + strconv.Itoa(i)
+			vnodes = append(vnodes, vnode{hash: hashString(key), host: host})
+		}
+	}
+	sort.Slice(vnodes, func(i, j int) bool {
+		return vnodes[i].hash < vnodes[j].hash
+	})
+	ring := &hashRing{
+		points: make([]uint32, len(vnodes)),
+		hosts:  make([]types.Host, len(vnodes)),
+	}
+	for i, n := range vnodes {
+		ring.points[i] = n.hash
+		ring.hosts[i] = n.host
+	}
+	ring.sourceLen, ring.sourcePtr = hostSliceIdentity(hosts)
+	return ring
+}
+
+func hashString(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
+
+func hashBytes(b []byte) uint32 {
+	h := fnv.New32a()
+	h.Write(b)
+	return h.Sum32()
+}
+
+// consistentHashLoadBalancer picks a host by hashing a per-request key (source
+// IP, header, or cookie, depending on keyFunc) onto a ring of virtual nodes,
+// so the same key keeps landing on the same host as the HostSet churns.
+type consistentHashLoadBalancer struct {
+	hosts   types.HostSet
+	keyFunc hashKeyFunc
+
+	mutex sync.Mutex
+	ring  atomic.Value // *hashRing
+}
+
+func newConsistentHashLoadBalancerFactory(keyFunc hashKeyFunc) func(types.HostSet) types.LoadBalancer {
+	return func(hosts types.HostSet) types.LoadBalancer {
+		lb := &consistentHashLoadBalancer{
+			hosts:   hosts,
+			keyFunc: keyFunc,
+		}
+		lb.ring.Store(buildHashRing(hosts.HealthyHosts()))
+		return lb
+	}
+}
+
+// configureConsistentHash swaps keyFunc for one built from the per-cluster
+// header/cookie name, overriding whatever default the LB type was
+// registered with.
+func (lb *consistentHashLoadBalancer) configureConsistentHash(config types.LoadBalancerConsistentHashConfig) {
+	if config.Source == types.LoadBalancerHashKeySourceCookie {
+		lb.keyFunc = cookieHashKey(config.Name)
+	} else {
+		lb.keyFunc = headerHashKey(config.Name)
+	}
+}
+
+// currentRing returns the ring for the current HostSet, rebuilding it first
+// if membership changed since the last pick.
+func (lb *consistentHashLoadBalancer) currentRing() *hashRing {
+	healthy := lb.hosts.HealthyHosts()
+	length, ptr := hostSliceIdentity(healthy)
+
+	if ring := lb.ring.Load().(*hashRing); ring.sourceLen == length && ring.sourcePtr == ptr {
+		return ring
+	}
+
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	// re-check under the lock: another goroutine may have already rebuilt it.
+	healthy = lb.hosts.HealthyHosts()
+	length, ptr = hostSliceIdentity(healthy)
+	if ring := lb.ring.Load().(*hashRing); ring.sourceLen == length && ring.sourcePtr == ptr {
+		return ring
+	}
+	ring := buildHashRing(healthy)
+	lb.ring.Store(ring)
+	return ring
+}
+
+func (lb *consistentHashLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	ring := lb.currentRing()
+	key, ok := lb.keyFunc(context)
+	if !ok {
+		// The configured header/cookie isn't present on this request: fall
+		// back to the source IP (as genericHashKey already does) instead of
+		// hashing a nil key, which would collapse every such request onto
+		// the same ring position.
+		key, _ = ipHashKey(context)
+	}
+	return ring.choose(hashBytes(key))
+}
+
+func (lb *consistentHashLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *consistentHashLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
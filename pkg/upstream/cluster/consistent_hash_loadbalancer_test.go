@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestConsistentHashStickyUnderChurn(t *testing.T) {
+	hosts := newMockHostSet(
+		newMockHost("host-1", 1, true),
+		newMockHost("host-2", 1, true),
+		newMockHost("host-3", 1, true),
+	)
+	lb := newConsistentHashLoadBalancerFactory(headerHashKey("x-key"))(hosts).(*consistentHashLoadBalancer)
+
+	ctx := newMockLBContext(map[string]string{"x-key": "customer-42"})
+	picked := lb.ChooseHost(ctx)
+	if picked == nil {
+		t.Fatal("expected a host to be chosen")
+	}
+
+	// Adding an unrelated host should not move the key to a different host
+	// most of the time; the same key must stick to the same host it had
+	// before the ring grew, unless its owning vnode happened to be the one
+	// that got displaced.
+	hosts.set(append(hosts.Hosts(), newMockHost("host-4", 1, true)))
+	again := lb.ChooseHost(ctx)
+	if again == nil {
+		t.Fatal("expected a host to be chosen after churn")
+	}
+	if again != picked && again.AddressString() != "host-4" {
+		t.Fatalf("expected the key to stay on %s or move to the new host-4, got %s", picked.AddressString(), again.AddressString())
+	}
+
+	// Removing a healthy host entirely should still resolve to some healthy host.
+	hosts.set([]types.Host{hosts.Hosts()[0], hosts.Hosts()[1]})
+	afterRemoval := lb.ChooseHost(ctx)
+	if afterRemoval == nil || !afterRemoval.Health() {
+		t.Fatalf("expected a healthy host after removal, got %v", afterRemoval)
+	}
+}
+
+func TestConsistentHashWeightProportionalDistribution(t *testing.T) {
+	light := newMockHost("light", 1, true)
+	heavy := newMockHost("heavy", 4, true)
+	hosts := newMockHostSet(light, heavy)
+	lb := newConsistentHashLoadBalancerFactory(headerHashKey("x-key"))(hosts).(*consistentHashLoadBalancer)
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		ctx := newMockLBContext(map[string]string{"x-key": mockKey(i)})
+		host := lb.ChooseHost(ctx)
+		counts[host.AddressString()]++
+	}
+
+	ratio := float64(counts["heavy"]) / float64(counts["light"])
+	if ratio < 2.5 || ratio > 6 {
+		t.Fatalf("expected heavy host to receive roughly 4x traffic of light host, got ratio %f (counts=%v)", ratio, counts)
+	}
+}
+
+func TestConsistentHashUnhealthyHostIsSkipped(t *testing.T) {
+	healthy := newMockHost("healthy", 1, true)
+	unhealthy := newMockHost("unhealthy", 1, false)
+	hosts := newMockHostSet(healthy, unhealthy)
+	lb := newConsistentHashLoadBalancerFactory(ipHashKey)(hosts).(*consistentHashLoadBalancer)
+
+	ctx := newMockLBContext(nil)
+	for i := 0; i < 50; i++ {
+		host := lb.ChooseHost(ctx)
+		if host == nil || !host.Health() {
+			t.Fatalf("ChooseHost returned unhealthy or nil host: %v", host)
+		}
+	}
+}
+
+func TestConsistentHashMissingHeaderFallsBackToIP(t *testing.T) {
+	hosts := newMockHostSet(
+		newMockHost("host-1", 1, true),
+		newMockHost("host-2", 1, true),
+		newMockHost("host-3", 1, true),
+	)
+	lb := newConsistentHashLoadBalancerFactory(headerHashKey(defaultConsistentHashHeader))(hosts).(*consistentHashLoadBalancer)
+
+	// None of these requests carry the configured header, so without an IP
+	// fallback they'd all hash to the same nil key and collapse onto a
+	// single host.
+	picked := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		ctx := &mockLBContext{conn: &mockConnection{remoteAddr: mockAddr(mockKey(i) + ":5000")}, headers: &mockHeaderMap{}}
+		host := lb.ChooseHost(ctx)
+		if host == nil {
+			t.Fatal("expected a host to be chosen")
+		}
+		picked[host.AddressString()] = true
+	}
+	if len(picked) < 2 {
+		t.Fatalf("expected requests with no configured header to spread across hosts by IP, all landed on %v", picked)
+	}
+}
+
+func TestConsistentHashConfigOverridesKeySource(t *testing.T) {
+	hosts := newMockHostSet(newMockHost("host-1", 1, true))
+	lb := NewLoadBalancerWithConfig(types.HeaderHash, hosts,
+		types.LoadBalancerStickyConfig{}, types.LoadBalancerSubsetConfig{}, types.LoadBalancerSlowStartConfig{},
+		types.LoadBalancerConsistentHashConfig{Source: types.LoadBalancerHashKeySourceHeader, Name: "x-tenant"},
+	).(*consistentHashLoadBalancer)
+
+	ctx := newMockLBContext(map[string]string{"x-tenant": "customer-42"})
+	if host := lb.ChooseHost(ctx); host == nil {
+		t.Fatal("expected a host to be chosen using the configured header name")
+	}
+}
+
+func mockKey(i int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 8)
+	for j := range b {
+		b[j] = letters[(i*7+j*31)%len(letters)]
+	}
+	return string(b)
+}
@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// interleavedWeightedRoundRobinLoadBalancer spreads picks evenly across
+// weighted hosts instead of the bursty earliest-deadline-first scheduling
+// EdfLoadBalancer does (weights {1,1,8} would otherwise pick the heavy host
+// 8 times in a row). It precomputes an interleaved pick schedule and walks it
+// with a monotonic counter, which is lock-free on the hot path.
+type interleavedWeightedRoundRobinLoadBalancer struct {
+	hosts types.HostSet
+
+	mutex    sync.Mutex
+	schedule atomic.Value // *interleavedSchedule
+	current  uint32
+}
+
+// interleavedSchedule pairs a precomputed pick schedule with the healthy-host
+// slice identity it was built from, so refresh() can tell cheaply whether a
+// rebuild is needed.
+type interleavedSchedule struct {
+	hosts     []types.Host
+	sourceLen int
+	sourcePtr uintptr
+}
+
+func newInterleavedWeightedRoundRobinLoadBalancer(hosts types.HostSet) types.LoadBalancer {
+	lb := &interleavedWeightedRoundRobinLoadBalancer{
+		hosts: hosts,
+	}
+	lb.schedule.Store(newInterleavedScheduleFor(hosts.HealthyHosts()))
+	return lb
+}
+
+func newInterleavedScheduleFor(healthy []types.Host) *interleavedSchedule {
+	length, ptr := hostSliceIdentity(healthy)
+	return &interleavedSchedule{
+		hosts:     buildInterleavedSchedule(healthy),
+		sourceLen: length,
+		sourcePtr: ptr,
+	}
+}
+
+// buildInterleavedSchedule repeatedly emits every host whose weight is still
+// >= round, for round = 1..max(weight), so heavier hosts reappear more often
+// but never more than one round ahead of lighter ones.
+func buildInterleavedSchedule(hosts []types.Host) []types.Host {
+	if len(hosts) == 0 {
+		return nil
+	}
+	maxWeight := uint32(0)
+	for _, host := range hosts {
+		if w := host.Weight(); w > maxWeight {
+			maxWeight = w
+		}
+	}
+	if maxWeight == 0 {
+		maxWeight = 1
+	}
+
+	schedule := make([]types.Host, 0, len(hosts)*int(maxWeight))
+	for round := uint32(1); round <= maxWeight; round++ {
+		for _, host := range hosts {
+			weight := host.Weight()
+			if weight == 0 {
+				weight = 1
+			}
+			if weight >= round {
+				schedule = append(schedule, host)
+			}
+		}
+	}
+	return schedule
+}
+
+// refresh rebuilds the interleaved schedule if healthy-host membership or
+// weights have changed since the last build, and is a no-op otherwise.
+func (lb *interleavedWeightedRoundRobinLoadBalancer) refresh() *interleavedSchedule {
+	healthy := lb.hosts.HealthyHosts()
+	length, ptr := hostSliceIdentity(healthy)
+
+	if cur := lb.schedule.Load().(*interleavedSchedule); cur.sourceLen == length && cur.sourcePtr == ptr {
+		return cur
+	}
+
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	healthy = lb.hosts.HealthyHosts()
+	length, ptr = hostSliceIdentity(healthy)
+	if cur := lb.schedule.Load().(*interleavedSchedule); cur.sourceLen == length && cur.sourcePtr == ptr {
+		return cur
+	}
+	schedule := newInterleavedScheduleFor(healthy)
+	lb.schedule.Store(schedule)
+	return schedule
+}
+
+func (lb *interleavedWeightedRoundRobinLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	schedule := lb.refresh()
+	total := len(schedule.hosts)
+	if total == 0 {
+		return nil
+	}
+	for i := 0; i < total; i++ {
+		index := atomic.AddUint32(&lb.current, 1) % uint32(total)
+		host := schedule.hosts[index]
+		if host.Health() {
+			return host
+		}
+	}
+	return nil
+}
+
+func (lb *interleavedWeightedRoundRobinLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *interleavedWeightedRoundRobinLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
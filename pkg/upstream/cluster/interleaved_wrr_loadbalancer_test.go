@@ -0,0 +1,160 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestInterleavedWRRSpreadsHeavyHostEvenly(t *testing.T) {
+	hosts := newMockHostSet(
+		newMockHost("a", 1, true),
+		newMockHost("b", 1, true),
+		newMockHost("c", 8, true),
+	)
+	lb := newInterleavedWeightedRoundRobinLoadBalancer(hosts)
+
+	var picks []string
+	for i := 0; i < 10; i++ {
+		picks = append(picks, lb.ChooseHost(nil).AddressString())
+	}
+
+	// With weights {1,1,8} EDF schedules the heavy host 8x in a row; IWRR
+	// must not produce a run of the heavy host anywhere near that long.
+	longestRun, run, last := 0, 0, ""
+	for _, p := range picks {
+		if p == last {
+			run++
+		} else {
+			run = 1
+			last = p
+		}
+		if run > longestRun {
+			longestRun = run
+		}
+	}
+	if longestRun > 3 {
+		t.Fatalf("expected interleaved picks, got a run of %d identical picks: %v", longestRun, picks)
+	}
+}
+
+func TestInterleavedWRRSkipsUnhealthyHost(t *testing.T) {
+	hosts := newMockHostSet(
+		newMockHost("a", 1, false),
+		newMockHost("b", 1, true),
+	)
+	lb := newInterleavedWeightedRoundRobinLoadBalancer(hosts)
+
+	for i := 0; i < 20; i++ {
+		host := lb.ChooseHost(nil)
+		if host == nil || host.AddressString() != "b" {
+			t.Fatalf("expected only the healthy host to be picked, got %v", host)
+		}
+	}
+}
+
+// benchmarkHostWeights mirrors the {light, medium, heavy} HostSet both
+// distribution benchmarks below are built from.
+var benchmarkHostWeights = map[string]uint32{"light": 1, "medium": 4, "heavy": 8}
+
+// benchmarkSampleCap bounds how many picks are kept for the variance
+// calculation, independent of b.N, so the benchmark can run for as long as
+// the framework likes without the sample slice's memory growing with it.
+// It's a multiple of the {1,4,8} weights' sum (13) so every window below is
+// a complete weighted rotation.
+const benchmarkSampleCap = 130 * 13
+
+// distributionVariance buckets picks into windows the size of one full
+// weighted rotation (the sum of weights) and returns the variance, across
+// all windows and hosts, of how far a host's per-window pick count deviates
+// from its ideal proportional share. It sees only per-window counts, not the
+// order of picks inside a window: a schedule that repeats exactly once per
+// weight sum scores zero however its picks are grouped within the window.
+func distributionVariance(picks []string, weights map[string]uint32) float64 {
+	var total uint32
+	for _, w := range weights {
+		total += w
+	}
+	windowSize := int(total)
+	if windowSize == 0 || len(picks) < windowSize {
+		return 0
+	}
+	numWindows := len(picks) / windowSize
+
+	var sumSquaredDeviation float64
+	samples := 0
+	for host, weight := range weights {
+		ideal := float64(weight)
+		for w := 0; w < numWindows; w++ {
+			actual := 0
+			for _, p := range picks[w*windowSize : (w+1)*windowSize] {
+				if p == host {
+					actual++
+				}
+			}
+			deviation := float64(actual) - ideal
+			sumSquaredDeviation += deviation * deviation
+			samples++
+		}
+	}
+	return sumSquaredDeviation / float64(samples)
+}
+
+func BenchmarkInterleavedWRRDistribution(b *testing.B) {
+	hosts := newMockHostSet(
+		newMockHost("light", 1, true),
+		newMockHost("medium", 4, true),
+		newMockHost("heavy", 8, true),
+	)
+	lb := newInterleavedWeightedRoundRobinLoadBalancer(hosts)
+	picks := make([]string, 0, benchmarkSampleCap)
+	for i := 0; i < b.N; i++ {
+		host := lb.ChooseHost(nil).AddressString()
+		if len(picks) < benchmarkSampleCap {
+			picks = append(picks, host)
+		}
+	}
+	b.ReportMetric(distributionVariance(picks, benchmarkHostWeights), "pick-variance")
+}
+
+func BenchmarkEdfDistribution(b *testing.B) {
+	hosts := newMockHostSet(
+		newMockHost("light", 1, true),
+		newMockHost("medium", 4, true),
+		newMockHost("heavy", 8, true),
+	)
+	lb := &EdfLoadBalancer{
+		hosts:          hosts,
+		rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
+		unweightChoose: func(types.LoadBalancerContext) types.Host { return nil },
+		HostWight:      func(host types.Host) uint32 { return host.Weight() },
+	}
+	lb.refresh(hosts.HealthyHosts())
+	picks := make([]string, 0, benchmarkSampleCap)
+	for i := 0; i < b.N; i++ {
+		host := lb.ChooseHost(nil).AddressString()
+		if len(picks) < benchmarkSampleCap {
+			picks = append(picks, host)
+		}
+	}
+	b.ReportMetric(distributionVariance(picks, benchmarkHostWeights), "pick-variance")
+}
@@ -0,0 +1,180 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"net"
+	"sync"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// mockHost is a minimal types.Host fake. It embeds the (nil) interface so it
+// satisfies types.Host without reimplementing every method; tests only ever
+// exercise the handful of methods the load balancers actually call.
+type mockHost struct {
+	types.Host
+
+	addr    string
+	weight  uint32
+	healthy bool
+	meta    api.Metadata
+
+	mutex  sync.Mutex
+	active int64
+	stats  *mockHostStats
+}
+
+func newMockHost(addr string, weight uint32, healthy bool) *mockHost {
+	return &mockHost{
+		addr:    addr,
+		weight:  weight,
+		healthy: healthy,
+		stats:   &mockHostStats{},
+	}
+}
+
+func newMockHostWithMeta(addr string, weight uint32, healthy bool, meta api.Metadata) *mockHost {
+	h := newMockHost(addr, weight, healthy)
+	h.meta = meta
+	return h
+}
+
+func (h *mockHost) AddressString() string { return h.addr }
+func (h *mockHost) Weight() uint32        { return h.weight }
+func (h *mockHost) Health() bool          { return h.healthy }
+func (h *mockHost) Metadata() api.Metadata { return h.meta }
+
+func (h *mockHost) HostStats() types.HostStats {
+	return types.HostStats{UpstreamRequestActive: h.stats}
+}
+
+func (h *mockHost) setActive(n int64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.stats.count = n
+}
+
+// mockHostStats is a minimal gauge implementation standing in for the real
+// metrics gauge returned by types.HostStats.UpstreamRequestActive.
+type mockHostStats struct {
+	count int64
+}
+
+func (s *mockHostStats) Count() int64 { return s.count }
+
+// mockHostSet is a minimal types.HostSet fake backed by a plain slice, with a
+// set() helper so tests can simulate membership churn between picks. Like the
+// real cluster HostSet, HealthyHosts caches its filtered slice across calls
+// so callers that rely on hostSliceIdentity can actually observe "no
+// membership change" instead of seeing a fresh allocation on every call.
+type mockHostSet struct {
+	types.HostSet
+
+	mutex   sync.Mutex
+	hosts   []types.Host
+	healthy []types.Host
+}
+
+func newMockHostSet(hosts ...types.Host) *mockHostSet {
+	s := &mockHostSet{hosts: hosts}
+	s.healthy = filterHealthy(hosts)
+	return s
+}
+
+func (s *mockHostSet) Hosts() []types.Host {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.hosts
+}
+
+func (s *mockHostSet) HealthyHosts() []types.Host {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.healthy
+}
+
+func filterHealthy(hosts []types.Host) []types.Host {
+	healthy := make([]types.Host, 0, len(hosts))
+	for _, h := range hosts {
+		if h.Health() {
+			healthy = append(healthy, h)
+		}
+	}
+	return healthy
+}
+
+func (s *mockHostSet) set(hosts []types.Host) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.hosts = hosts
+	s.healthy = filterHealthy(hosts)
+}
+
+// mockAddr is a throwaway net.Addr used to stand in for a downstream
+// connection's remote address.
+type mockAddr string
+
+func (a mockAddr) Network() string { return "tcp" }
+func (a mockAddr) String() string  { return string(a) }
+
+type mockConnection struct {
+	api.Connection
+
+	remoteAddr net.Addr
+}
+
+func (c *mockConnection) RemoteAddr() net.Addr { return c.remoteAddr }
+
+// mockHeaderMap is a minimal api.HeaderMap fake backed by a plain map.
+type mockHeaderMap struct {
+	api.HeaderMap
+
+	values map[string]string
+}
+
+func (m *mockHeaderMap) Get(key string) (string, bool) {
+	if m == nil || m.values == nil {
+		return "", false
+	}
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// mockLBContext is a minimal types.LoadBalancerContext fake.
+type mockLBContext struct {
+	types.LoadBalancerContext
+
+	conn    *mockConnection
+	headers *mockHeaderMap
+	meta    api.MetadataMatchCriteria
+}
+
+func newMockLBContext(headers map[string]string) *mockLBContext {
+	return &mockLBContext{
+		conn:    &mockConnection{remoteAddr: mockAddr("10.0.0.1:5000")},
+		headers: &mockHeaderMap{values: headers},
+	}
+}
+
+func (c *mockLBContext) DownstreamConnection() api.Connection { return c.conn }
+func (c *mockLBContext) DownstreamHeaders() api.HeaderMap     { return c.headers }
+func (c *mockLBContext) MetadataMatchCriteria() api.MetadataMatchCriteria {
+	return c.meta
+}
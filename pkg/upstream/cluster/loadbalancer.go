@@ -46,21 +46,49 @@ func init() {
 	RegisterLBType(types.RoundRobin, rrFactory.newRoundRobinLoadBalancer)
 	RegisterLBType(types.Random, newRandomLoadBalancer)
 	RegisterLBType(types.LeastActiveRequest, newleastActiveRequestLoadBalancer)
+	RegisterLBType(types.ConsistentHash, newConsistentHashLoadBalancerFactory(genericHashKey))
+	RegisterLBType(types.IPHash, newConsistentHashLoadBalancerFactory(ipHashKey))
+	RegisterLBType(types.HeaderHash, newConsistentHashLoadBalancerFactory(headerHashKey(defaultConsistentHashHeader)))
+	RegisterLBType(types.InterleavedWRR, newInterleavedWeightedRoundRobinLoadBalancer)
+	RegisterLBType(types.PeakEWMA, newPeakEWMALoadBalancer)
 }
 
 func NewLoadBalancer(lbType types.LoadBalancerType, hosts types.HostSet) types.LoadBalancer {
+	return NewLoadBalancerWithConfig(lbType, hosts, types.LoadBalancerStickyConfig{}, types.LoadBalancerSubsetConfig{}, types.LoadBalancerSlowStartConfig{}, types.LoadBalancerConsistentHashConfig{})
+}
+
+// NewLoadBalancerWithSticky builds the load balancer for lbType same as
+// NewLoadBalancer, additionally wrapping it with cookie/header affinity when
+// sticky.Name is set.
+func NewLoadBalancerWithSticky(lbType types.LoadBalancerType, hosts types.HostSet, sticky types.LoadBalancerStickyConfig) types.LoadBalancer {
+	return NewLoadBalancerWithConfig(lbType, hosts, sticky, types.LoadBalancerSubsetConfig{}, types.LoadBalancerSlowStartConfig{}, types.LoadBalancerConsistentHashConfig{})
+}
+
+// NewLoadBalancerWithConfig builds the load balancer for lbType, additionally
+// configuring subset-aware selection when subset.Keys is set, ramping newly
+// joined hosts' weight when slowStart.Window is set, overriding the
+// consistent-hash key source when hash.Name is set, and wrapping the result
+// with sticky-session affinity when sticky.Name is set.
+func NewLoadBalancerWithConfig(lbType types.LoadBalancerType, hosts types.HostSet, sticky types.LoadBalancerStickyConfig, subset types.LoadBalancerSubsetConfig, slowStart types.LoadBalancerSlowStartConfig, hash types.LoadBalancerConsistentHashConfig) types.LoadBalancer {
+	var base types.LoadBalancer
 	if f, ok := lbFactories[lbType]; ok {
-		return f(hosts)
+		base = f(hosts)
+	} else {
+		base = rrFactory.newRoundRobinLoadBalancer(hosts)
 	}
-	return rrFactory.newRoundRobinLoadBalancer(hosts)
+	applyConsistentHashConfig(base, hash)
+	applySubsetConfig(base, subset)
+	applySlowStartConfig(base, slowStart)
+	return wrapWithStickySession(base, hosts, sticky)
 }
 
 // LoadBalancer Implementations
 
 type randomLoadBalancer struct {
-	mutex sync.Mutex
-	rand  *rand.Rand
-	hosts types.HostSet
+	mutex  sync.Mutex
+	rand   *rand.Rand
+	hosts  types.HostSet
+	subset *subsetIndex
 }
 
 func newRandomLoadBalancer(hosts types.HostSet) types.LoadBalancer {
@@ -70,7 +98,18 @@ func newRandomLoadBalancer(hosts types.HostSet) types.LoadBalancer {
 	}
 }
 
+func (lb *randomLoadBalancer) configureSubset(config types.LoadBalancerSubsetConfig) {
+	lb.subset = newSubsetIndex(config, newRandomLoadBalancer)
+}
+
 func (lb *randomLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	if lb.subset != nil {
+		return lb.subset.chooseHost(lb.hosts.HealthyHosts(), context, lb.chooseWithoutSubset)
+	}
+	return lb.chooseWithoutSubset(context)
+}
+
+func (lb *randomLoadBalancer) chooseWithoutSubset(context types.LoadBalancerContext) types.Host {
 	targets := lb.hosts.Hosts()
 	total := len(targets)
 	if total == 0 {
@@ -89,17 +128,27 @@ func (lb *randomLoadBalancer) ChooseHost(context types.LoadBalancerContext) type
 	return nil
 }
 
+// IsExistsHosts and HostNum both report against HealthyHosts, matching what
+// ChooseHost can actually return, so upstream routing gates on the same
+// notion of "available" a pick would use.
 func (lb *randomLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
-	return len(lb.hosts.Hosts()) > 0
+	if lb.subset != nil {
+		return lb.subset.existsHosts(lb.hosts.HealthyHosts(), metadata)
+	}
+	return len(lb.hosts.HealthyHosts()) > 0
 }
 
 func (lb *randomLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
-	return len(lb.hosts.Hosts())
+	if lb.subset != nil {
+		return lb.subset.hostNum(lb.hosts.HealthyHosts(), metadata)
+	}
+	return len(lb.hosts.HealthyHosts())
 }
 
 type roundRobinLoadBalancer struct {
 	hosts   types.HostSet
 	rrIndex uint32
+	subset  *subsetIndex
 }
 
 type roundRobinLoadBalancerFactory struct {
@@ -121,7 +170,18 @@ func (f *roundRobinLoadBalancerFactory) newRoundRobinLoadBalancer(hosts types.Ho
 	}
 }
 
+func (lb *roundRobinLoadBalancer) configureSubset(config types.LoadBalancerSubsetConfig) {
+	lb.subset = newSubsetIndex(config, rrFactory.newRoundRobinLoadBalancer)
+}
+
 func (lb *roundRobinLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	if lb.subset != nil {
+		return lb.subset.chooseHost(lb.hosts.HealthyHosts(), context, lb.chooseWithoutSubset)
+	}
+	return lb.chooseWithoutSubset(context)
+}
+
+func (lb *roundRobinLoadBalancer) chooseWithoutSubset(context types.LoadBalancerContext) types.Host {
 	targets := lb.hosts.Hosts()
 	total := len(targets)
 	if total == 0 {
@@ -137,12 +197,21 @@ func (lb *roundRobinLoadBalancer) ChooseHost(context types.LoadBalancerContext)
 	return nil
 }
 
+// IsExistsHosts and HostNum both report against HealthyHosts, matching what
+// ChooseHost can actually return, so upstream routing gates on the same
+// notion of "available" a pick would use.
 func (lb *roundRobinLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
-	return len(lb.hosts.Hosts()) > 0
+	if lb.subset != nil {
+		return lb.subset.existsHosts(lb.hosts.HealthyHosts(), metadata)
+	}
+	return len(lb.hosts.HealthyHosts()) > 0
 }
 
 func (lb *roundRobinLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
-	return len(lb.hosts.Hosts())
+	if lb.subset != nil {
+		return lb.subset.hostNum(lb.hosts.HealthyHosts(), metadata)
+	}
+	return len(lb.hosts.HealthyHosts())
 }
 
 // leastActiveRequestLoadBalancer choose the host with the least active request
@@ -159,6 +228,24 @@ func newleastActiveRequestLoadBalancer(hosts types.HostSet) types.LoadBalancer {
 	return lb
 }
 
+// configureSubset overrides EdfLoadBalancer.configureSubset: a generic
+// EdfLoadBalancer child would close over this instance's unweightChooseHost,
+// which reads active-request counts through this instance's own hosts
+// rather than the subset's, so each subset instead gets its own complete
+// leastActiveRequestLoadBalancer scoped to that subset's hosts.
+func (lb *leastActiveRequestLoadBalancer) configureSubset(config types.LoadBalancerSubsetConfig) {
+	lb.subset = newSubsetIndex(config, func(hosts types.HostSet) types.LoadBalancer {
+		child := newleastActiveRequestLoadBalancer(hosts).(*leastActiveRequestLoadBalancer)
+		// Inherit slow start like EdfLoadBalancer.configureSubset's children, so
+		// refreshSubsetChildren also keeps this child's ramp moving.
+		if lb.slowStart != nil {
+			child.slowStart = lb.slowStart
+			child.refresh(hosts.HealthyHosts())
+		}
+		return child
+	})
+}
+
 func (lb *leastActiveRequestLoadBalancer) unweightChooseHost(context types.LoadBalancerContext) types.Host {
 
 	healthyHosts := lb.hosts.HealthyHosts()
@@ -193,9 +280,6 @@ func (lb *leastActiveRequestLoadBalancer) unweightChooseHost(context types.LoadB
 
 }
 
-// TODO:
-// WRR
-
 type EdfLoadBalancer struct {
 	scheduler *edfSchduler
 	hosts     types.HostSet
@@ -204,10 +288,57 @@ type EdfLoadBalancer struct {
 	// the method to choose host when all host
 	unweightChoose func(types.LoadBalancerContext) types.Host
 	HostWight      func(host types.Host) uint32
+	subset         *subsetIndex
+	slowStart      *slowStartState
+	// stop is closed by Close to tear down the slow-start rebuild ticker
+	// goroutine started by configureSlowStart; nil when slow start isn't
+	// configured.
+	stop chan struct{}
+}
+
+// Close stops the background slow-start rebuild ticker, if one was started
+// by configureSlowStart. Callers that replace an EdfLoadBalancer (e.g. on a
+// HostSet rebuild) must call Close on the old instance so its ticker
+// goroutine doesn't keep running against stale state forever.
+func (lb *EdfLoadBalancer) Close() {
+	lb.mutex.Lock()
+	stop := lb.stop
+	lb.stop = nil
+	lb.mutex.Unlock()
+	if stop != nil {
+		close(stop)
+	}
+}
+
+func (lb *EdfLoadBalancer) configureSubset(config types.LoadBalancerSubsetConfig) {
+	unweightChoose := lb.unweightChoose
+	hostWeight := lb.HostWight
+	lb.subset = newSubsetIndex(config, func(hosts types.HostSet) types.LoadBalancer {
+		child := &EdfLoadBalancer{
+			hosts:          hosts,
+			rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
+			unweightChoose: unweightChoose,
+			HostWight:      hostWeight,
+			// Read lb.slowStart here rather than snapshotting it into a local
+			// above: this factory runs lazily, after NewLoadBalancerWithConfig
+			// has applied every config (slow start included), so by the time a
+			// subset's first child is built lb.slowStart already reflects the
+			// final configuration regardless of apply order.
+			slowStart: lb.slowStart,
+		}
+		child.refresh(hosts.HealthyHosts())
+		return child
+	})
 }
 
 func (lb *EdfLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	if lb.subset != nil {
+		return lb.subset.chooseHost(lb.hosts.HealthyHosts(), context, lb.chooseWithoutSubset)
+	}
+	return lb.chooseWithoutSubset(context)
+}
 
+func (lb *EdfLoadBalancer) chooseWithoutSubset(context types.LoadBalancerContext) types.Host {
 	if lb.scheduler != nil {
 		// do weight selection
 		host := lb.scheduler.Next().(types.Host)
@@ -218,12 +349,27 @@ func (lb *EdfLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.H
 	}
 }
 
+// IsExistsHosts and HostNum both report against HealthyHosts in the subset
+// and non-subset paths alike, matching what ChooseHost can actually return
+// (and what every other LoadBalancer implementation in this file reports),
+// so upstream routing gates on a consistent notion of "available" regardless
+// of which LB type a cluster picks.
 func (lb *EdfLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
-	return len(lb.hosts.Hosts()) > 0
+	if lb.subset != nil {
+		// Feed the subset index the same healthy-host slice ChooseHost does,
+		// so hostSliceIdentity sees a stable source and doesn't rebuild the
+		// subset snapshot (and every child balancer's state with it) on
+		// every single call.
+		return lb.subset.existsHosts(lb.hosts.HealthyHosts(), metadata)
+	}
+	return len(lb.hosts.HealthyHosts()) > 0
 }
 
 func (lb *EdfLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
-	return len(lb.hosts.Hosts())
+	if lb.subset != nil {
+		return lb.subset.hostNum(lb.hosts.HealthyHosts(), metadata)
+	}
+	return len(lb.hosts.HealthyHosts())
 }
 
 func newEdfLoadBalancerLoadBalancer(hosts types.HostSet, unWeightChoose func(types.LoadBalancerContext) types.Host) *EdfLoadBalancer {
@@ -237,8 +383,13 @@ func newEdfLoadBalancerLoadBalancer(hosts types.HostSet, unWeightChoose func(typ
 }
 
 func (lb *EdfLoadBalancer) refresh(hosts []types.Host) {
-	// Check if the original host weights are equal and skip EDF creation if they are
-	if hostWeightsAreEqual(hosts) {
+	if lb.slowStart != nil {
+		// A newly-joined host never has the same effective weight as its
+		// peers, even when its configured weight does, so the equal-weight
+		// shortcut below doesn't apply while the ramp is active.
+		lb.slowStart.prune(hostAddressSet(hosts), time.Now())
+	} else if hostWeightsAreEqual(hosts) {
+		// Check if the original host weights are equal and skip EDF creation if they are
 		return
 	}
 
@@ -246,7 +397,7 @@ func (lb *EdfLoadBalancer) refresh(hosts []types.Host) {
 
 	// Init Edf scheduler with healthy hosts.
 	for _, host := range hosts {
-		lb.scheduler.Add(host, lb.HostWight(host))
+		lb.scheduler.Add(host, lb.DynamicHostWeight(host))
 	}
 
 }
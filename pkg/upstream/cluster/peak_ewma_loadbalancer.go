@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// defaultEwmaDecayTau is the time constant used to decay the response-time
+// average; after this long a latency spike has decayed to 1/e (about 37%)
+// of its size.
+const defaultEwmaDecayTau = 10 * time.Second
+
+// ewmaSample is the sidecar latency state for one host. types.Host can't be
+// extended with this without a broader refactor, so it's kept in a map
+// keyed by host address instead.
+type ewmaSample struct {
+	nanos      float64
+	lastUpdate int64 // UnixNano
+}
+
+// decayed returns the sample's value decayed forward to now, without
+// mutating the stored sample; reads are what age out a host that's gone
+// idle so it can be probed again.
+func (s *ewmaSample) decayed(now int64) float64 {
+	if s == nil {
+		return 0
+	}
+	elapsed := time.Duration(now - s.lastUpdate)
+	if elapsed <= 0 {
+		return s.nanos
+	}
+	decay := math.Exp(-float64(elapsed) / float64(defaultEwmaDecayTau))
+	return s.nanos * decay
+}
+
+// peakEWMALoadBalancer picks the host with the lowest estimated cost, where
+// cost is an exponentially-weighted moving average of observed response
+// times multiplied by (1 + active requests). It samples `choice` healthy
+// hosts per pick (Power-of-Two-Choices) the same way
+// leastActiveRequestLoadBalancer does, scoring on estimated latency instead
+// of raw active-request count.
+type peakEWMALoadBalancer struct {
+	hosts  types.HostSet
+	choice int
+
+	mutex   sync.Mutex
+	rand    *rand.Rand
+	samples map[string]*ewmaSample
+}
+
+func newPeakEWMALoadBalancer(hosts types.HostSet) types.LoadBalancer {
+	return &peakEWMALoadBalancer{
+		hosts:   hosts,
+		choice:  2,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		samples: make(map[string]*ewmaSample),
+	}
+}
+
+// Observe records a completed request's round-trip time against host,
+// updating its decayed moving average. The RPC/HTTP filter calls this on
+// every response completion.
+func (lb *peakEWMALoadBalancer) Observe(host types.Host, rtt time.Duration) {
+	now := time.Now().UnixNano()
+	key := host.AddressString()
+
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	prev := lb.samples[key]
+	if prev == nil {
+		lb.samples[key] = &ewmaSample{nanos: float64(rtt), lastUpdate: now}
+		return
+	}
+	decayed := prev.decayed(now)
+	lb.samples[key] = &ewmaSample{
+		nanos:      decayed + (float64(rtt)-decayed)*ewmaGain(now, prev.lastUpdate),
+		lastUpdate: now,
+	}
+}
+
+// ewmaGain is the (1-decay) weight given to a fresh sample; decay = exp(-Δt/τ).
+func ewmaGain(now, lastUpdate int64) float64 {
+	elapsed := time.Duration(now - lastUpdate)
+	if elapsed <= 0 {
+		return 1
+	}
+	return 1 - math.Exp(-float64(elapsed)/float64(defaultEwmaDecayTau))
+}
+
+// cost must be called with lb.mutex held. An unobserved host floors its cost
+// at its active request count alone, rather than at the sample-multiplied
+// zero an unobserved host would otherwise get: the (1+active) multiplier
+// only pulls its weight once there's a real latency sample to multiply, so
+// without this floor a burst of concurrent picks would keep piling onto an
+// unobserved host instead of being gated by its growing active count the
+// same way an observed host's cost already is.
+func (lb *peakEWMALoadBalancer) cost(host types.Host, now int64) float64 {
+	sample := lb.samples[host.AddressString()]
+	active := host.HostStats().UpstreamRequestActive.Count()
+	if sample == nil {
+		return float64(active)
+	}
+	return sample.decayed(now) * float64(active+1)
+}
+
+func (lb *peakEWMALoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	healthy := lb.hosts.HealthyHosts()
+	total := len(healthy)
+	if total == 0 {
+		return nil
+	}
+	if total == 1 {
+		return healthy[0]
+	}
+
+	choice := lb.choice
+	if choice > total {
+		choice = total
+	}
+	now := time.Now().UnixNano()
+
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	var best types.Host
+	bestCost := math.MaxFloat64
+	for i := 0; i < choice; i++ {
+		candidate := healthy[lb.rand.Intn(total)]
+		if cost := lb.cost(candidate, now); best == nil || cost < bestCost {
+			best = candidate
+			bestCost = cost
+		}
+	}
+	return best
+}
+
+func (lb *peakEWMALoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *peakEWMALoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
+
+var _ types.ObservableLoadBalancer = (*peakEWMALoadBalancer)(nil)
@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeakEWMAPrefersFasterHost(t *testing.T) {
+	fast := newMockHost("fast", 1, true)
+	slow := newMockHost("slow", 1, true)
+	hosts := newMockHostSet(fast, slow)
+
+	lb := newPeakEWMALoadBalancer(hosts).(*peakEWMALoadBalancer)
+	// Both samples are drawn with replacement, so a pick sees both hosts only
+	// half the time and the fast host is expected to win about 3/4 of picks.
+	lb.choice = 2
+	lb.Observe(fast, 5*time.Millisecond)
+	lb.Observe(slow, 200*time.Millisecond)
+
+	fastWins := 0
+	for i := 0; i < 200; i++ {
+		if lb.ChooseHost(nil).AddressString() == "fast" {
+			fastWins++
+		}
+	}
+	if fastWins < 120 {
+		t.Fatalf("expected the consistently faster host to win most picks, got %d/200", fastWins)
+	}
+}
+
+func TestPeakEWMAUnobservedHostCostsByActiveRequests(t *testing.T) {
+	fresh := newMockHost("fresh", 1, true)
+	hosts := newMockHostSet(fresh)
+	lb := newPeakEWMALoadBalancer(hosts).(*peakEWMALoadBalancer)
+
+	if cost := lb.cost(fresh, time.Now().UnixNano()); cost != 0 {
+		t.Fatalf("expected an unobserved, idle host to start at zero cost, got %f", cost)
+	}
+
+	// Without a latency sample to multiply, the (1+active) cold-start
+	// protection only works if active requests alone floor the cost: an
+	// unobserved host taking on concurrent requests must look costlier than
+	// one sitting idle.
+	fresh.setActive(3)
+	if cost := lb.cost(fresh, time.Now().UnixNano()); cost != 3 {
+		t.Fatalf("expected an unobserved host's cost to floor at its active request count, got %f", cost)
+	}
+}
+
+func TestPeakEWMADecaysStaleSamples(t *testing.T) {
+	host := newMockHost("host", 1, true)
+	hosts := newMockHostSet(host)
+	lb := newPeakEWMALoadBalancer(hosts).(*peakEWMALoadBalancer)
+	lb.Observe(host, 500*time.Millisecond)
+
+	now := time.Now().UnixNano()
+	freshCost := lb.cost(host, now)
+	laterCost := lb.cost(host, now+int64(2*defaultEwmaDecayTau))
+	if laterCost >= freshCost {
+		t.Fatalf("expected cost to decay over time, got fresh=%f later=%f", freshCost, laterCost)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// slowStartRebuildSlices is how many times per slowStartWindow the EDF
+// scheduler is rebuilt while slow start is active, since EDF deadlines are
+// computed once at Add time and won't otherwise reflect a ramping weight.
+const slowStartRebuildSlices = 10
+
+// slowStartConfigurable is implemented by load balancers that support
+// ramping a newly-joined host's weight up gradually (EdfLoadBalancer, and
+// leastActiveRequestLoadBalancer by embedding it).
+type slowStartConfigurable interface {
+	configureSlowStart(config types.LoadBalancerSlowStartConfig)
+}
+
+func applySlowStartConfig(lb types.LoadBalancer, config types.LoadBalancerSlowStartConfig) {
+	if config.Window <= 0 {
+		return
+	}
+	if configurable, ok := lb.(slowStartConfigurable); ok {
+		configurable.configureSlowStart(config)
+	}
+}
+
+// slowStartState tracks when each host address was first observed, so
+// DynamicHostWeight can scale a newly-joined host's effective weight by
+// min(1, (now-firstSeen)/window)^aggression until the window elapses.
+type slowStartState struct {
+	window     time.Duration
+	aggression float64
+	cooldown   time.Duration
+
+	mutex     sync.Mutex
+	firstSeen map[string]time.Time
+	lastSeen  map[string]time.Time
+}
+
+func newSlowStartState(config types.LoadBalancerSlowStartConfig) *slowStartState {
+	if config.Window <= 0 {
+		return nil
+	}
+	aggression := config.Aggression
+	if aggression <= 0 {
+		aggression = 1
+	}
+	return &slowStartState{
+		window:     config.Window,
+		aggression: aggression,
+		cooldown:   config.Cooldown,
+		firstSeen:  make(map[string]time.Time),
+		lastSeen:   make(map[string]time.Time),
+	}
+}
+
+// prune forgets the ramp progress of hosts that have been absent from
+// present for longer than the configured cooldown, so a later rejoin starts
+// a fresh ramp. Hosts that left and came back within the cooldown keep
+// whatever progress they already had instead of being penalized for
+// flapping.
+func (s *slowStartState) prune(present map[string]bool, now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for addr, seenAt := range s.lastSeen {
+		if present[addr] {
+			continue
+		}
+		if s.cooldown <= 0 || now.Sub(seenAt) > s.cooldown {
+			delete(s.firstSeen, addr)
+			delete(s.lastSeen, addr)
+		}
+	}
+}
+
+// scale returns addr's ramp progress in [0, 1] as of now, starting the ramp
+// the first time addr is seen.
+func (s *slowStartState) scale(addr string, now time.Time) float64 {
+	s.mutex.Lock()
+	first, known := s.firstSeen[addr]
+	if !known {
+		first = now
+		s.firstSeen[addr] = first
+	}
+	s.lastSeen[addr] = now
+	s.mutex.Unlock()
+
+	elapsed := now.Sub(first)
+	if elapsed >= s.window {
+		return 1
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return math.Pow(float64(elapsed)/float64(s.window), s.aggression)
+}
+
+func hostAddressSet(hosts []types.Host) map[string]bool {
+	set := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		set[host.AddressString()] = true
+	}
+	return set
+}
+
+// configureSlowStart enables gradual weight ramp-up for newly-joined hosts
+// and starts a background rebuild loop, since a ramping weight needs the EDF
+// scheduler rebuilt periodically even when HostSet membership doesn't change.
+func (lb *EdfLoadBalancer) configureSlowStart(config types.LoadBalancerSlowStartConfig) {
+	lb.slowStart = newSlowStartState(config)
+	if lb.slowStart == nil {
+		return
+	}
+	lb.refresh(lb.hosts.HealthyHosts())
+	lb.stop = make(chan struct{})
+	go lb.runSlowStartTicker(lb.slowStart.window/slowStartRebuildSlices, lb.stop)
+}
+
+// runSlowStartTicker rebuilds the EDF scheduler on interval until stop is
+// closed, which happens when the owning EdfLoadBalancer's Close is called.
+func (lb *EdfLoadBalancer) runSlowStartTicker(interval time.Duration, stop chan struct{}) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			lb.refresh(lb.hosts.HealthyHosts())
+			lb.refreshSubsetChildren()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// refreshSubsetChildren rebuilds the EDF scheduler of every live subset child
+// that inherited this balancer's slow-start state. Only the parent runs a
+// slow-start ticker, so without this a subset child's ramp is only ever
+// applied once, at the moment it was lazily built, and then freezes there
+// instead of converging like the parent's.
+func (lb *EdfLoadBalancer) refreshSubsetChildren() {
+	if lb.subset == nil {
+		return
+	}
+	snap := lb.subset.snapshot.Load().(*subsetSnapshot)
+	for _, child := range snap.children {
+		if edf := edfBalancerOf(child); edf != nil && edf.slowStart != nil {
+			edf.refresh(edf.hosts.HealthyHosts())
+		}
+	}
+}
+
+// edfBalancerOf unwraps the *EdfLoadBalancer a subset child is or embeds, so
+// refreshSubsetChildren can drive its refresh regardless of whether the
+// subset's factory builds a plain EdfLoadBalancer or a
+// leastActiveRequestLoadBalancer wrapping one.
+func edfBalancerOf(lb types.LoadBalancer) *EdfLoadBalancer {
+	switch v := lb.(type) {
+	case *EdfLoadBalancer:
+		return v
+	case *leastActiveRequestLoadBalancer:
+		return v.EdfLoadBalancer
+	}
+	return nil
+}
+
+// DynamicHostWeight is HostWight augmented with the slow-start ramp: a host
+// within its slow-start window gets a fraction of its configured weight, and
+// hosts that have completed the ramp (or have no slow-start configured) get
+// their full weight unchanged.
+func (lb *EdfLoadBalancer) DynamicHostWeight(host types.Host) uint32 {
+	full := host.Weight()
+	if lb.HostWight != nil {
+		full = lb.HostWight(host)
+	}
+	if full == 0 {
+		full = 1
+	}
+	if lb.slowStart == nil {
+		return full
+	}
+
+	scaled := uint32(math.Ceil(float64(full) * lb.slowStart.scale(host.AddressString(), time.Now())))
+	if scaled == 0 {
+		scaled = 1
+	}
+	return scaled
+}
@@ -0,0 +1,203 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// newTestEdfLoadBalancer builds an EdfLoadBalancer directly rather than
+// through configureSlowStart, so tests can drive refresh() themselves
+// instead of waiting on the background rebuild ticker.
+func newTestEdfLoadBalancer(hosts types.HostSet, slowStart types.LoadBalancerSlowStartConfig) *EdfLoadBalancer {
+	lb := &EdfLoadBalancer{
+		hosts:          hosts,
+		unweightChoose: func(types.LoadBalancerContext) types.Host { return nil },
+		slowStart:      newSlowStartState(slowStart),
+	}
+	lb.refresh(hosts.HealthyHosts())
+	return lb
+}
+
+func TestSlowStartNewHostStartsNearZeroShare(t *testing.T) {
+	veteran := newMockHost("veteran", 1, true)
+	hosts := newMockHostSet(veteran)
+	lb := newTestEdfLoadBalancer(hosts, types.LoadBalancerSlowStartConfig{Window: time.Minute})
+
+	// veteran was first seen by the refresh above, so it is ramping too, but a
+	// weight-1 host is clamped to the minimum weight of 1 throughout.
+	if w := lb.DynamicHostWeight(veteran); w != 1 {
+		t.Fatalf("expected the existing host to keep its full weight, got %d", w)
+	}
+
+	newcomer := newMockHost("newcomer", 1, true)
+	hosts.set([]types.Host{veteran, newcomer})
+	lb.refresh(hosts.HealthyHosts())
+
+	if w := lb.DynamicHostWeight(newcomer); w != 1 {
+		t.Fatalf("expected a brand new host's weight to be clamped to the minimum of 1, got %d", w)
+	}
+
+	// Directly check the ramp progress rather than the clamped weight, since
+	// weight 1 is both "just joined" and "fully warmed" for a weight-1 host.
+	if scale := lb.slowStart.scale("newcomer", time.Now()); scale > 0.05 {
+		t.Fatalf("expected a freshly-joined host's ramp progress to start near zero, got %f", scale)
+	}
+}
+
+func TestSlowStartConvergesToSteadyState(t *testing.T) {
+	host := newMockHost("host", 10, true)
+	hosts := newMockHostSet(host)
+	window := 50 * time.Millisecond
+	lb := newTestEdfLoadBalancer(hosts, types.LoadBalancerSlowStartConfig{Window: window})
+
+	if w := lb.DynamicHostWeight(host); w >= 10 {
+		t.Fatalf("expected a reduced weight immediately after joining, got %d", w)
+	}
+
+	time.Sleep(window + 10*time.Millisecond)
+	if w := lb.DynamicHostWeight(host); w != 10 {
+		t.Fatalf("expected weight to converge to the configured weight after the window elapses, got %d", w)
+	}
+}
+
+func TestSlowStartSkipsRampOnQuickRejoin(t *testing.T) {
+	host := newMockHost("flapping", 1, true)
+	hosts := newMockHostSet(host)
+	lb := newTestEdfLoadBalancer(hosts, types.LoadBalancerSlowStartConfig{
+		Window:   time.Minute,
+		Cooldown: time.Hour,
+	})
+
+	// Let the ramp fully complete once.
+	lb.slowStart.firstSeen["flapping"] = time.Now().Add(-2 * time.Minute)
+	lb.slowStart.lastSeen["flapping"] = time.Now()
+	if scale := lb.slowStart.scale("flapping", time.Now()); scale != 1 {
+		t.Fatalf("expected the ramp to be complete before the flap, got %f", scale)
+	}
+
+	// Host briefly leaves and rejoins well within the cooldown window.
+	hosts.set(nil)
+	lb.refresh(hosts.HealthyHosts())
+	hosts.set([]types.Host{host})
+	lb.refresh(hosts.HealthyHosts())
+
+	if scale := lb.slowStart.scale("flapping", time.Now()); scale != 1 {
+		t.Fatalf("expected a quick rejoin within the cooldown to keep full ramp progress, got %f", scale)
+	}
+}
+
+func TestSlowStartTickerStopsOnClose(t *testing.T) {
+	host := newMockHost("host", 1, true)
+	hosts := newMockHostSet(host)
+	lb := &EdfLoadBalancer{
+		hosts:          hosts,
+		unweightChoose: func(types.LoadBalancerContext) types.Host { return nil },
+	}
+	lb.configureSlowStart(types.LoadBalancerSlowStartConfig{Window: 20 * time.Millisecond})
+
+	// Let the background ticker rebuild the scheduler at least once, then
+	// tear the load balancer down the way a HostSet rebuild would.
+	time.Sleep(10 * time.Millisecond)
+	lb.Close()
+	settled := lb.scheduler
+
+	time.Sleep(30 * time.Millisecond)
+	if lb.scheduler != settled {
+		t.Fatalf("expected Close to stop the slow-start ticker, but the scheduler kept rebuilding")
+	}
+
+	// Close must be safe to call more than once (e.g. double teardown).
+	lb.Close()
+}
+
+// TestSlowStartAppliesToSubsetChildren guards against configureSubset
+// building a per-subset child that doesn't inherit the parent's slow-start
+// state: without it, a newly-joined host in a subset-matched cluster gets
+// its full weight immediately instead of ramping up.
+func TestSlowStartAppliesToSubsetChildren(t *testing.T) {
+	veteran := newMockHostWithMeta("veteran", 10, true, api.Metadata{"version": "v1"})
+	hosts := newMockHostSet(veteran)
+
+	lb := &EdfLoadBalancer{
+		hosts:          hosts,
+		unweightChoose: func(types.LoadBalancerContext) types.Host { return nil },
+	}
+	lb.configureSlowStart(types.LoadBalancerSlowStartConfig{Window: time.Minute})
+	defer lb.Close()
+	lb.configureSubset(types.LoadBalancerSubsetConfig{Keys: []string{"version"}})
+
+	newcomer := newMockHostWithMeta("newcomer", 10, true, api.Metadata{"version": "v1"})
+	hosts.set([]types.Host{veteran, newcomer})
+
+	ctx := &mockLBContext{meta: newMockMatchCriteria(map[string]string{"version": "v1"})}
+	lb.ChooseHost(ctx) // builds the v1 subset child lazily
+
+	key := canonicalSubsetKey(map[string]string{"version": "v1"}, []string{"version"})
+	snap := lb.subset.snapshot.Load().(*subsetSnapshot)
+	child, ok := snap.children[key].(*EdfLoadBalancer)
+	if !ok {
+		t.Fatalf("expected the v1 subset child to be an *EdfLoadBalancer, got %T", snap.children[key])
+	}
+	if child.slowStart == nil {
+		t.Fatal("expected the subset child to inherit the parent's slow-start state")
+	}
+	if w := child.DynamicHostWeight(newcomer); w >= 10 {
+		t.Fatalf("expected the subset child to ramp a newly-joined host's weight, got %d", w)
+	}
+}
+
+// TestSlowStartTickerRefreshesSubsetChildren guards against the parent's
+// slow-start ticker only rebuilding its own scheduler: a subset child's EDF
+// scheduler is built once, lazily, when the child is first created, so
+// without the parent's ticker also refreshing it, a ramping host's weight
+// inside a subset would freeze at whatever it was the moment the child was
+// built instead of converging over the window like the parent's.
+func TestSlowStartTickerRefreshesSubsetChildren(t *testing.T) {
+	veteran := newMockHostWithMeta("veteran", 10, true, api.Metadata{"version": "v1"})
+	hosts := newMockHostSet(veteran)
+
+	lb := &EdfLoadBalancer{
+		hosts:          hosts,
+		unweightChoose: func(types.LoadBalancerContext) types.Host { return nil },
+	}
+	lb.configureSlowStart(types.LoadBalancerSlowStartConfig{Window: 20 * time.Millisecond})
+	defer lb.Close()
+	lb.configureSubset(types.LoadBalancerSubsetConfig{Keys: []string{"version"}})
+
+	ctx := &mockLBContext{meta: newMockMatchCriteria(map[string]string{"version": "v1"})}
+	lb.ChooseHost(ctx) // builds the v1 subset child lazily
+
+	key := canonicalSubsetKey(map[string]string{"version": "v1"}, []string{"version"})
+	snap := lb.subset.snapshot.Load().(*subsetSnapshot)
+	child, ok := snap.children[key].(*EdfLoadBalancer)
+	if !ok {
+		t.Fatalf("expected the v1 subset child to be an *EdfLoadBalancer, got %T", snap.children[key])
+	}
+	settled := child.scheduler
+
+	// Let the parent's slow-start ticker fire at least once.
+	time.Sleep(10 * time.Millisecond)
+	if child.scheduler == settled {
+		t.Fatal("expected the parent's slow-start ticker to also rebuild the subset child's scheduler")
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// StickyDecision tells the HTTP/RPC layer whether it should stamp a sticky
+// cookie/header onto the response for the host that was chosen, so the next
+// request from the same client lands back on it. TTL and Secure only apply
+// when Mode is cookie-based; the HTTP layer uses them for the cookie's
+// Max-Age and Secure attributes and ignores them for header mode.
+type StickyDecision struct {
+	Set    bool
+	Mode   types.LoadBalancerStickyMode
+	Name   string
+	Value  string
+	TTL    time.Duration
+	Secure bool
+}
+
+// StickyLoadBalancer is implemented by load balancers wrapped with sticky
+// session support. Callers that don't care about affinity can keep using the
+// plain types.LoadBalancer.ChooseHost; the HTTP/RPC filter type-asserts to
+// this interface to learn whether a sticky cookie/header needs to be set.
+type StickyLoadBalancer interface {
+	types.LoadBalancer
+	ChooseHostSticky(context types.LoadBalancerContext) (types.Host, StickyDecision)
+}
+
+// stickyLookupFunc extracts the sticky token (cookie or header value, per
+// config) carried by an incoming request.
+type stickyLookupFunc func(context types.LoadBalancerContext) (string, bool)
+
+func stickyLookupFor(config types.LoadBalancerStickyConfig) stickyLookupFunc {
+	if config.Mode == types.LoadBalancerStickyModeHeader {
+		return func(context types.LoadBalancerContext) (string, bool) {
+			headers := context.DownstreamHeaders()
+			if headers == nil {
+				return "", false
+			}
+			return headers.Get(config.Name)
+		}
+	}
+	return func(context types.LoadBalancerContext) (string, bool) {
+		headers := context.DownstreamHeaders()
+		if headers == nil {
+			return "", false
+		}
+		raw, ok := headers.Get("cookie")
+		if !ok {
+			return "", false
+		}
+		value := parseCookie(raw, config.Name)
+		return value, value != ""
+	}
+}
+
+// stickyTable maps a host's address to itself, along with the healthy-host
+// slice identity it was built from so rebuilds can be skipped when nothing
+// changed.
+type stickyTable struct {
+	hosts     map[string]types.Host
+	sourceLen int
+	sourcePtr uintptr
+}
+
+func buildStickyTable(hosts []types.Host) *stickyTable {
+	table := make(map[string]types.Host, len(hosts))
+	for _, host := range hosts {
+		table[host.AddressString()] = host
+	}
+	length, ptr := hostSliceIdentity(hosts)
+	return &stickyTable{hosts: table, sourceLen: length, sourcePtr: ptr}
+}
+
+// stickySession wraps a base LoadBalancer (random, round-robin, or the base
+// EdfLoadBalancer) with cookie/header affinity: if the incoming request
+// carries a sticky token that maps to a currently-healthy host, that host is
+// returned directly; otherwise selection falls through to the wrapped
+// algorithm and the caller is told to stamp the token for next time.
+type stickySession struct {
+	types.LoadBalancer
+
+	hosts  types.HostSet
+	config types.LoadBalancerStickyConfig
+	lookup stickyLookupFunc
+
+	mutex sync.Mutex
+	table atomic.Value // *stickyTable
+}
+
+// wrapWithStickySession returns base unchanged when sticky sessions aren't
+// configured for this cluster.
+func wrapWithStickySession(base types.LoadBalancer, hosts types.HostSet, config types.LoadBalancerStickyConfig) types.LoadBalancer {
+	if config.Name == "" {
+		return base
+	}
+	s := &stickySession{
+		LoadBalancer: base,
+		hosts:        hosts,
+		config:       config,
+		lookup:       stickyLookupFor(config),
+	}
+	s.table.Store(buildStickyTable(hosts.HealthyHosts()))
+	return s
+}
+
+func (s *stickySession) currentTable() *stickyTable {
+	healthy := s.hosts.HealthyHosts()
+	length, ptr := hostSliceIdentity(healthy)
+
+	if table := s.table.Load().(*stickyTable); table.sourceLen == length && table.sourcePtr == ptr {
+		return table
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	healthy = s.hosts.HealthyHosts()
+	length, ptr = hostSliceIdentity(healthy)
+	if table := s.table.Load().(*stickyTable); table.sourceLen == length && table.sourcePtr == ptr {
+		return table
+	}
+	table := buildStickyTable(healthy)
+	s.table.Store(table)
+	return table
+}
+
+func (s *stickySession) ChooseHost(context types.LoadBalancerContext) types.Host {
+	host, _ := s.ChooseHostSticky(context)
+	return host
+}
+
+func (s *stickySession) ChooseHostSticky(context types.LoadBalancerContext) (types.Host, StickyDecision) {
+	table := s.currentTable()
+	if token, ok := s.lookup(context); ok {
+		// A host leaving the HostSet simply falls back to normal selection
+		// below; it must never surface as an error to the caller.
+		if host, found := table.hosts[token]; found && host.Health() {
+			return host, StickyDecision{}
+		}
+	}
+
+	host := s.LoadBalancer.ChooseHost(context)
+	if host == nil {
+		return nil, StickyDecision{}
+	}
+	return host, StickyDecision{
+		Set:    true,
+		Mode:   s.config.Mode,
+		Name:   s.config.Name,
+		Value:  host.AddressString(),
+		TTL:    s.config.TTL,
+		Secure: s.config.Secure,
+	}
+}
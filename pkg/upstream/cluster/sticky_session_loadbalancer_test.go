@@ -0,0 +1,110 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestStickySessionHeaderAffinity(t *testing.T) {
+	hosts := newMockHostSet(
+		newMockHost("host-1", 1, true),
+		newMockHost("host-2", 1, true),
+	)
+	lb := NewLoadBalancerWithSticky(types.Random, hosts, types.LoadBalancerStickyConfig{
+		Mode: types.LoadBalancerStickyModeHeader,
+		Name: "x-sticky",
+	}).(StickyLoadBalancer)
+
+	ctx := newMockLBContext(map[string]string{})
+	first, decision := lb.ChooseHostSticky(ctx)
+	if first == nil || !decision.Set {
+		t.Fatalf("expected a fresh pick with a sticky decision to set, got host=%v decision=%v", first, decision)
+	}
+
+	// A follow-up request carrying the token the first pick handed back must
+	// stick to the same host, without being told to set it again.
+	ctx2 := newMockLBContext(map[string]string{"x-sticky": decision.Value})
+	second, decision2 := lb.ChooseHostSticky(ctx2)
+	if second.AddressString() != first.AddressString() {
+		t.Fatalf("expected sticky pick to return %s, got %s", first.AddressString(), second.AddressString())
+	}
+	if decision2.Set {
+		t.Fatal("expected no sticky decision when the token already resolves to a healthy host")
+	}
+}
+
+func TestStickySessionFallsBackWhenHostLeaves(t *testing.T) {
+	stuck := newMockHost("stuck", 1, true)
+	other := newMockHost("other", 1, true)
+	hosts := newMockHostSet(stuck, other)
+	lb := NewLoadBalancerWithSticky(types.RoundRobin, hosts, types.LoadBalancerStickyConfig{
+		Mode: types.LoadBalancerStickyModeCookie,
+		Name: "sid",
+	}).(StickyLoadBalancer)
+
+	ctx := newMockLBContext(map[string]string{"cookie": "sid=stuck"})
+	host, _ := lb.ChooseHostSticky(ctx)
+	if host.AddressString() != "stuck" {
+		t.Fatalf("expected sticky host 'stuck', got %s", host.AddressString())
+	}
+
+	// Once the sticky host leaves the HostSet, the same token must fall back
+	// to normal selection instead of erroring out.
+	hosts.set([]types.Host{other})
+	host, decision := lb.ChooseHostSticky(ctx)
+	if host == nil || host.AddressString() != "other" {
+		t.Fatalf("expected fallback to the remaining host, got %v", host)
+	}
+	if !decision.Set {
+		t.Fatal("expected a fresh sticky decision once the old host is gone")
+	}
+}
+
+func TestStickySessionDecisionCarriesCookieTTLAndSecure(t *testing.T) {
+	hosts := newMockHostSet(newMockHost("host-1", 1, true))
+	lb := NewLoadBalancerWithSticky(types.Random, hosts, types.LoadBalancerStickyConfig{
+		Mode:   types.LoadBalancerStickyModeCookie,
+		Name:   "sid",
+		TTL:    time.Hour,
+		Secure: true,
+	}).(StickyLoadBalancer)
+
+	ctx := newMockLBContext(map[string]string{})
+	_, decision := lb.ChooseHostSticky(ctx)
+	if !decision.Set {
+		t.Fatal("expected a fresh pick to carry a sticky decision")
+	}
+	if decision.TTL != time.Hour {
+		t.Fatalf("expected the configured TTL to reach the sticky decision, got %s", decision.TTL)
+	}
+	if !decision.Secure {
+		t.Fatal("expected the configured secure flag to reach the sticky decision")
+	}
+}
+
+func TestNewLoadBalancerWithoutStickyConfigIsUnwrapped(t *testing.T) {
+	hosts := newMockHostSet(newMockHost("host-1", 1, true))
+	lb := NewLoadBalancer(types.Random, hosts)
+	if _, ok := lb.(StickyLoadBalancer); ok {
+		t.Fatal("expected a plain load balancer when no sticky config is given")
+	}
+}
@@ -0,0 +1,263 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// subsetConfigurable is implemented by the load balancers that support
+// subset-aware selection (random, round-robin, and EdfLoadBalancer, which
+// leastActiveRequestLoadBalancer inherits by embedding it).
+type subsetConfigurable interface {
+	configureSubset(config types.LoadBalancerSubsetConfig)
+}
+
+func applySubsetConfig(lb types.LoadBalancer, config types.LoadBalancerSubsetConfig) {
+	if len(config.Keys) == 0 {
+		return
+	}
+	if configurable, ok := lb.(subsetConfigurable); ok {
+		configurable.configureSubset(config)
+	}
+}
+
+// subsetHostSet is a read-only types.HostSet view over a fixed slice of
+// hosts belonging to one subset. It's handed to a balancer's own factory so
+// the child instance it builds is scoped to just that subset.
+type subsetHostSet struct {
+	types.HostSet
+
+	hosts []types.Host
+}
+
+func (s *subsetHostSet) Hosts() []types.Host { return s.hosts }
+
+func (s *subsetHostSet) HealthyHosts() []types.Host {
+	healthy := make([]types.Host, 0, len(s.hosts))
+	for _, h := range s.hosts {
+		if h.Health() {
+			healthy = append(healthy, h)
+		}
+	}
+	return healthy
+}
+
+// canonicalSubsetKey builds a stable key from the declared subset keys and a
+// set of label values, so the same label set always hashes to the same
+// subset regardless of map iteration order.
+func canonicalSubsetKey(values map[string]string, keys []string) string {
+	var b strings.Builder
+	for _, k := range keys {
+		v, ok := values[k]
+		if !ok {
+			continue
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(v)
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+func hostMetadataValues(host types.Host, keys []string) map[string]string {
+	meta := host.Metadata()
+	values := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if v, ok := meta[k]; ok {
+			values[k] = v
+		}
+	}
+	return values
+}
+
+// criteriaSubsetKey canonicalizes a request's metadata match criteria the
+// same way hostMetadataValues does for a host. ok is false when the request
+// didn't carry any match criteria at all.
+func criteriaSubsetKey(criteria api.MetadataMatchCriteria, keys []string) (key string, ok bool) {
+	if criteria == nil {
+		return "", false
+	}
+	criterions := criteria.MetadataMatchCriteria()
+	if len(criterions) == 0 {
+		return "", false
+	}
+	values := make(map[string]string, len(criterions))
+	for _, c := range criterions {
+		values[c.MetadataKeyName()] = c.MetadataValue()
+	}
+	return canonicalSubsetKey(values, keys), true
+}
+
+// subsetSnapshot groups the hosts known to a subsetIndex by subset key, along
+// with one lazily-built child LoadBalancer per subset, and the healthy-host
+// slice identity it was built from.
+type subsetSnapshot struct {
+	bySubset  map[string][]types.Host
+	children  map[string]types.LoadBalancer
+	sourceLen int
+	sourcePtr uintptr
+}
+
+// subsetIndex restricts a load balancer's candidate hosts to the subset
+// matching a request's metadata match criteria. It's built from the cluster
+// config's declared subset-selector keys, and rebuilt under a mutex (with an
+// atomic pointer swap for lock-free reads) whenever HostSet membership or
+// host metadata changes.
+type subsetIndex struct {
+	keys       []string
+	fallback   types.LoadBalancerSubsetFallbackPolicy
+	defaultKey string
+	factory    func(types.HostSet) types.LoadBalancer
+
+	mutex    sync.Mutex
+	snapshot atomic.Value // *subsetSnapshot
+}
+
+// newSubsetIndex returns nil when no subset-selector keys are configured, so
+// callers can treat a nil *subsetIndex as "subset matching disabled".
+func newSubsetIndex(config types.LoadBalancerSubsetConfig, factory func(types.HostSet) types.LoadBalancer) *subsetIndex {
+	if len(config.Keys) == 0 {
+		return nil
+	}
+	keys := append([]string(nil), config.Keys...)
+	sort.Strings(keys)
+
+	idx := &subsetIndex{
+		keys:     keys,
+		fallback: config.Fallback,
+		factory:  factory,
+	}
+	if len(config.DefaultSubset) > 0 {
+		idx.defaultKey = canonicalSubsetKey(config.DefaultSubset, keys)
+	}
+	idx.snapshot.Store(&subsetSnapshot{
+		bySubset: map[string][]types.Host{},
+		children: map[string]types.LoadBalancer{},
+	})
+	return idx
+}
+
+func (idx *subsetIndex) refresh(hosts []types.Host) *subsetSnapshot {
+	length, ptr := hostSliceIdentity(hosts)
+	if cur := idx.snapshot.Load().(*subsetSnapshot); cur.sourceLen == length && cur.sourcePtr == ptr {
+		return cur
+	}
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	if cur := idx.snapshot.Load().(*subsetSnapshot); cur.sourceLen == length && cur.sourcePtr == ptr {
+		return cur
+	}
+
+	bySubset := make(map[string][]types.Host)
+	for _, host := range hosts {
+		key := canonicalSubsetKey(hostMetadataValues(host, idx.keys), idx.keys)
+		bySubset[key] = append(bySubset[key], host)
+	}
+	children := make(map[string]types.LoadBalancer, len(bySubset))
+	for key, subsetHosts := range bySubset {
+		children[key] = idx.factory(&subsetHostSet{hosts: subsetHosts})
+	}
+	snap := &subsetSnapshot{
+		bySubset:  bySubset,
+		children:  children,
+		sourceLen: length,
+		sourcePtr: ptr,
+	}
+	idx.snapshot.Store(snap)
+	return snap
+}
+
+// chooseHost picks a host from the subset matching context, applying the
+// configured fallback policy. If context carries no match criteria at all,
+// selection defers entirely to noSubset (the balancer's normal algorithm).
+func (idx *subsetIndex) chooseHost(hosts []types.Host, context types.LoadBalancerContext, noSubset func(types.LoadBalancerContext) types.Host) types.Host {
+	var criteria api.MetadataMatchCriteria
+	if context != nil {
+		criteria = context.MetadataMatchCriteria()
+	}
+	key, ok := criteriaSubsetKey(criteria, idx.keys)
+	if !ok {
+		return noSubset(context)
+	}
+
+	snap := idx.refresh(hosts)
+	if child, found := snap.children[key]; found {
+		return child.ChooseHost(context)
+	}
+	switch idx.fallback {
+	case types.LoadBalancerSubsetFallbackDefault:
+		if idx.defaultKey != "" {
+			if child, found := snap.children[idx.defaultKey]; found {
+				return child.ChooseHost(context)
+			}
+		}
+		return nil
+	case types.LoadBalancerSubsetFallbackNone:
+		return nil
+	default: // any-endpoint
+		return noSubset(context)
+	}
+}
+
+// hostsFor returns the subset-restricted hosts matching criteria, applying
+// the configured fallback policy. ok is false when criteria doesn't select a
+// subset at all; callers should then treat hosts as unrestricted.
+func (idx *subsetIndex) hostsFor(hosts []types.Host, criteria api.MetadataMatchCriteria) (matched []types.Host, ok bool) {
+	key, has := criteriaSubsetKey(criteria, idx.keys)
+	if !has {
+		return nil, false
+	}
+	snap := idx.refresh(hosts)
+	if subset, found := snap.bySubset[key]; found {
+		return subset, true
+	}
+	switch idx.fallback {
+	case types.LoadBalancerSubsetFallbackDefault:
+		if idx.defaultKey != "" {
+			if subset, found := snap.bySubset[idx.defaultKey]; found {
+				return subset, true
+			}
+		}
+		return nil, true
+	case types.LoadBalancerSubsetFallbackNone:
+		return nil, true
+	default: // any-endpoint
+		return nil, false
+	}
+}
+
+func (idx *subsetIndex) hostNum(hosts []types.Host, criteria api.MetadataMatchCriteria) int {
+	matched, ok := idx.hostsFor(hosts, criteria)
+	if !ok {
+		return len(hosts)
+	}
+	return len(matched)
+}
+
+func (idx *subsetIndex) existsHosts(hosts []types.Host, criteria api.MetadataMatchCriteria) bool {
+	return idx.hostNum(hosts, criteria) > 0
+}
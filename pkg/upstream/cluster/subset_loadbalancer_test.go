@@ -0,0 +1,181 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// mockMatchCriterion/mockMatchCriteria implement the api.MetadataMatchCriteria
+// surface this package reads, so tests can assert subset restriction without
+// depending on a real router config.
+type mockMatchCriterion struct {
+	key   string
+	value string
+}
+
+func (c mockMatchCriterion) MetadataKeyName() string { return c.key }
+func (c mockMatchCriterion) MetadataValue() string   { return c.value }
+
+type mockMatchCriteria struct {
+	api.MetadataMatchCriteria
+
+	criterions []api.MetadataMatchCriterion
+}
+
+func newMockMatchCriteria(values map[string]string) *mockMatchCriteria {
+	criterions := make([]api.MetadataMatchCriterion, 0, len(values))
+	for k, v := range values {
+		criterions = append(criterions, mockMatchCriterion{key: k, value: v})
+	}
+	return &mockMatchCriteria{criterions: criterions}
+}
+
+func (c *mockMatchCriteria) MetadataMatchCriteria() []api.MetadataMatchCriterion {
+	return c.criterions
+}
+
+func TestSubsetRandomLoadBalancerRestrictsToMatchingSubset(t *testing.T) {
+	v1a := newMockHostWithMeta("v1-a", 1, true, api.Metadata{"version": "v1"})
+	v1b := newMockHostWithMeta("v1-b", 1, true, api.Metadata{"version": "v1"})
+	v2 := newMockHostWithMeta("v2", 1, true, api.Metadata{"version": "v2"})
+	hosts := newMockHostSet(v1a, v1b, v2)
+
+	lb := NewLoadBalancerWithConfig(types.Random, hosts, types.LoadBalancerStickyConfig{}, types.LoadBalancerSubsetConfig{
+		Keys: []string{"version"},
+	}, types.LoadBalancerSlowStartConfig{}, types.LoadBalancerConsistentHashConfig{})
+
+	ctx := &mockLBContext{meta: newMockMatchCriteria(map[string]string{"version": "v1"})}
+	for i := 0; i < 50; i++ {
+		host := lb.ChooseHost(ctx)
+		if host.AddressString() == "v2" {
+			t.Fatalf("expected only v1 hosts to be chosen, got %s", host.AddressString())
+		}
+	}
+
+	if n := lb.HostNum(ctx.meta); n != 2 {
+		t.Fatalf("expected 2 hosts in the v1 subset, got %d", n)
+	}
+	if !lb.IsExistsHosts(ctx.meta) {
+		t.Fatal("expected the v1 subset to be non-empty")
+	}
+}
+
+func TestSubsetFallbackNoneReturnsNoHost(t *testing.T) {
+	v1 := newMockHostWithMeta("v1", 1, true, api.Metadata{"version": "v1"})
+	hosts := newMockHostSet(v1)
+
+	lb := NewLoadBalancerWithConfig(types.RoundRobin, hosts, types.LoadBalancerStickyConfig{}, types.LoadBalancerSubsetConfig{
+		Keys:     []string{"version"},
+		Fallback: types.LoadBalancerSubsetFallbackNone,
+	}, types.LoadBalancerSlowStartConfig{}, types.LoadBalancerConsistentHashConfig{})
+
+	ctx := &mockLBContext{meta: newMockMatchCriteria(map[string]string{"version": "v3"})}
+	if host := lb.ChooseHost(ctx); host != nil {
+		t.Fatalf("expected no host for an unmatched subset with fallback=none, got %v", host)
+	}
+	if n := lb.HostNum(ctx.meta); n != 0 {
+		t.Fatalf("expected 0 hosts reported for an unmatched subset with fallback=none, got %d", n)
+	}
+}
+
+func TestSubsetFallbackAnyUsesWholeSet(t *testing.T) {
+	v1 := newMockHostWithMeta("v1", 1, true, api.Metadata{"version": "v1"})
+	hosts := newMockHostSet(v1)
+
+	lb := NewLoadBalancerWithConfig(types.RoundRobin, hosts, types.LoadBalancerStickyConfig{}, types.LoadBalancerSubsetConfig{
+		Keys:     []string{"version"},
+		Fallback: types.LoadBalancerSubsetFallbackAny,
+	}, types.LoadBalancerSlowStartConfig{}, types.LoadBalancerConsistentHashConfig{})
+
+	ctx := &mockLBContext{meta: newMockMatchCriteria(map[string]string{"version": "v3"})}
+	if host := lb.ChooseHost(ctx); host == nil {
+		t.Fatal("expected fallback=any to still return a host from the full set")
+	}
+}
+
+func TestSubsetLeastActiveLoadBalancerRestrictsCandidates(t *testing.T) {
+	v1 := newMockHostWithMeta("v1", 1, true, api.Metadata{"version": "v1"})
+	v2a := newMockHostWithMeta("v2-a", 1, true, api.Metadata{"version": "v2"})
+	v2b := newMockHostWithMeta("v2-b", 1, true, api.Metadata{"version": "v2"})
+	hosts := newMockHostSet(v1, v2a, v2b)
+
+	lb := NewLoadBalancerWithConfig(types.LeastActiveRequest, hosts, types.LoadBalancerStickyConfig{}, types.LoadBalancerSubsetConfig{
+		Keys: []string{"version"},
+	}, types.LoadBalancerSlowStartConfig{}, types.LoadBalancerConsistentHashConfig{})
+
+	ctx := &mockLBContext{meta: newMockMatchCriteria(map[string]string{"version": "v2"})}
+	for i := 0; i < 50; i++ {
+		host := lb.ChooseHost(ctx)
+		if host.AddressString() == "v1" {
+			t.Fatalf("expected only v2 hosts to be chosen, got %s", host.AddressString())
+		}
+	}
+}
+
+// TestSubsetSnapshotNotRebuiltWithoutHostSetChurn guards against ChooseHost
+// and IsExistsHosts/HostNum feeding the subset index different host slices
+// (HealthyHosts vs Hosts) for the same HostSet: that mismatch defeats
+// hostSliceIdentity's "rebuild lazily on membership change" caching and
+// rebuilds the subset snapshot - and every per-subset child balancer it
+// owns - on essentially every call.
+func TestSubsetSnapshotNotRebuiltWithoutHostSetChurn(t *testing.T) {
+	v1 := newMockHostWithMeta("v1", 1, true, api.Metadata{"version": "v1"})
+	v2 := newMockHostWithMeta("v2", 1, true, api.Metadata{"version": "v2"})
+	hosts := newMockHostSet(v1, v2)
+
+	lb := NewLoadBalancerWithConfig(types.LeastActiveRequest, hosts, types.LoadBalancerStickyConfig{}, types.LoadBalancerSubsetConfig{
+		Keys: []string{"version"},
+	}, types.LoadBalancerSlowStartConfig{}, types.LoadBalancerConsistentHashConfig{}).(*leastActiveRequestLoadBalancer)
+
+	ctx := &mockLBContext{meta: newMockMatchCriteria(map[string]string{"version": "v1"})}
+
+	// First call builds the snapshot; capture it only once it has settled.
+	lb.ChooseHost(ctx)
+	settled := lb.subset.snapshot.Load().(*subsetSnapshot)
+
+	lb.HostNum(ctx.meta)
+	lb.IsExistsHosts(ctx.meta)
+	lb.ChooseHost(ctx)
+
+	if got := lb.subset.snapshot.Load().(*subsetSnapshot); got != settled {
+		t.Fatal("expected the subset snapshot to stay unchanged when the HostSet didn't churn")
+	}
+}
+
+func TestSubsetRequestsWithoutCriteriaUseNormalSelection(t *testing.T) {
+	v1 := newMockHostWithMeta("v1", 1, true, api.Metadata{"version": "v1"})
+	v2 := newMockHostWithMeta("v2", 1, true, api.Metadata{"version": "v2"})
+	hosts := newMockHostSet(v1, v2)
+
+	lb := NewLoadBalancerWithConfig(types.Random, hosts, types.LoadBalancerStickyConfig{}, types.LoadBalancerSubsetConfig{
+		Keys: []string{"version"},
+	}, types.LoadBalancerSlowStartConfig{}, types.LoadBalancerConsistentHashConfig{})
+
+	seen := map[string]bool{}
+	ctx := newMockLBContext(nil)
+	for i := 0; i < 50; i++ {
+		seen[lb.ChooseHost(ctx).AddressString()] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected both hosts reachable without match criteria, got %v", seen)
+	}
+}